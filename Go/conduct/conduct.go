@@ -5,8 +5,7 @@ import (
 	_ "ChubbyGo/Config"
 	"ChubbyGo/Connect"
 	"conduct/lib"
-	"fmt"
-	"log"
+	"conduct/lib/log"
 	"os"
 	"time"
 
@@ -18,71 +17,71 @@ func main() {
 	clientConfigs := Connect.CreateClient()
 	err := clientConfigs.StartClient()
 	if err != nil {
-		log.Println(err.Error())
+		log.Errorf("%v", err)
 	}
 	clientConfigs.SetUniqueFlake(uint64(66)) // Use a random number for multiple tests
 	// Open a directory and get a handle
 	ok, fd := clientConfigs.Open("/ls/ChubbyCell_Conductor")
 	if ok {
-		fmt.Printf("Get fd success, instanceSeq is %d\n", fd.InstanceSeq)
+		log.Debugf(log.FacetLock, "get fd success, instanceSeq is %d", fd.InstanceSeq)
 	} else {
-		fmt.Printf("Error!\n")
+		log.Warnf("error opening /ls/ChubbyCell_Conductor")
 	}
 
 	filename := "start.sh"
 	// Create a file in the opened folder
 	ok, fileFd := clientConfigs.Create(fd, BaseServer.PermanentFile, filename)
 	if ok {
-		fmt.Printf("Create file(%s) success, instanceSeq is %d, checksum is %d.\n", filename, fileFd.InstanceSeq, fileFd.ChuckSum)
+		log.Debugf(log.FacetLock, "create file(%s) success, instanceSeq is %d, checksum is %d", filename, fileFd.InstanceSeq, fileFd.ChuckSum)
 	} else {
-		fmt.Printf("Create Error!\n")
+		log.Warnf("create error on %s", filename)
 	}
 
 	// Delete the handle, note that the handle is only created by create and deleted by delete
 	ok = clientConfigs.Delete(fileFd, BaseServer.Opdelete)
 	if ok {
-		fmt.Printf("Delete file(%s) success\n", filename)
+		log.Debugf(log.FacetLock, "delete file(%s) success", filename)
 	} else {
-		fmt.Printf("Delete Error!\n")
+		log.Warnf("delete error on %s", filename)
 	}
 
 	// Lock the newly created file
 	ok, token := clientConfigs.Acquire(fileFd, BaseServer.ReadLock, 0)
 	if ok {
-		fmt.Printf("Acquire (%s) success, Token is %d\n", filename, token)
+		log.Debugf(log.FacetLock, "acquire (%s) success, token is %d", filename, token)
 	} else {
-		fmt.Printf("Acquire Error!\n")
+		log.Warnf("acquire error on %s", filename)
 	}
 
 	// Delete the file with the token you locked yourself
 	ok = clientConfigs.Release(fileFd, token)
 	if ok {
-		fmt.Printf("release (%s) success.\n", filename)
+		log.Debugf(log.FacetLock, "release (%s) success", filename)
 	} else {
-		fmt.Printf("Release Error!\n")
+		log.Warnf("release error on %s", filename)
 	}
 
 	ok = clientConfigs.Release(fileFd, token)
 	if ok {
-		fmt.Printf("release (%s) success.\n", filename)
+		log.Debugf(log.FacetLock, "release (%s) success", filename)
 	} else {
-		fmt.Printf("Release Error!\n")
+		log.Warnf("release error on %s", filename)
 	}
 
 	ok, token = clientConfigs.Acquire(fileFd, BaseServer.WriteLock, 1000)
 	if ok {
-		fmt.Printf("Acquire (%s) success, Token is %d\n", filename, token)
+		log.Debugf(log.FacetLock, "acquire (%s) success, token is %d", filename, token)
 	} else { // Obviously, you can't add a read lock after adding a write lock
-		fmt.Printf("WriteLock Error!\n")
+		log.Warnf("write lock error on %s", filename)
 	}
 	// There will be problems when requesting data with this token after timeout, TODO but the request data with token has not been implemented yet
 
 	// Fail first
 	ok, token = clientConfigs.Acquire(fileFd, BaseServer.WriteLock, 0)
 	if ok {
-		fmt.Printf("Acquire (%s) success, Token is %d\n", filename, token)
+		log.Debugf(log.FacetLock, "acquire (%s) success, token is %d", filename, token)
 	} else { // Obviously, you can't add a read lock after adding a write lock
-		fmt.Printf("WriteLock Error!\n")
+		log.Warnf("write lock error on %s", filename)
 	}
 
 	// After 2000ms, you can lock again successfully because the previous lock has timed out
@@ -90,44 +89,42 @@ func main() {
 
 	ok, token = clientConfigs.Acquire(fileFd, BaseServer.WriteLock, 0)
 	if ok {
-		fmt.Printf("Acquire (%s) success, Token is %d\n", filename, token)
+		log.Debugf(log.FacetLock, "acquire (%s) success, token is %d", filename, token)
 	} else { // Obviously, you can't add a read lock after adding a write lock
-		fmt.Printf("WriteLock Error!\n")
+		log.Warnf("write lock error on %s", filename)
 	}
 
 	ok = clientConfigs.Release(fileFd, token)
 	if ok {
-		fmt.Printf("release (%s) success.\n", filename)
+		log.Debugf(log.FacetLock, "release (%s) success", filename)
 	} else {
-		fmt.Printf("Release Error!\n")
+		log.Warnf("release error on %s", filename)
 	}
 
 	// Using the token that has been unlocked above should return false
 	ok = clientConfigs.CheckToken(fileFd.PathName, token)
 	if ok {
-		fmt.Printf("CheckToken error! filename(%s)\n", fileFd.PathName)
+		log.Warnf("check token error! filename(%s)", fileFd.PathName)
 	} else {
-		fmt.Printf("CheckToken success!\n")
+		log.Debugf(log.FacetLock, "check token success")
 	}
 
 	// Finally, delete the file to facilitate testing lock_expand.go
 	ok = clientConfigs.Delete(fileFd, BaseServer.Opdelete)
 	if ok {
-		fmt.Printf("Delete file(%s) success\n", filename)
+		log.Debugf(log.FacetLock, "delete file(%s) success", filename)
 	} else {
-		fmt.Printf("Delete Error!\n")
+		log.Warnf("delete error on %s", filename)
 	}
 
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: conduct <config_file>")
-		os.Exit(1)
+		log.Fatalf("usage: conduct <config_file>")
 	}
 
 	configFile := os.Args[1]
 	cfg, err := ini.Load(configFile)
 	if err != nil {
-		fmt.Printf("Fail to read file: %v\n", err)
-		os.Exit(1)
+		log.Fatalf("fail to read file: %v", err)
 	}
 
 	// Example of reading values from the config file
@@ -141,8 +138,16 @@ func main() {
 		ResetPhase:   *lib.NewPhase(resultHost, resultPort),
 	}
 
-	client.Startup()
-	client.Run()
-	client.Collect()
-	client.Reset()
+	if err := client.Startup(); err != nil {
+		log.Fatalf("error running startup phase: %v", err)
+	}
+	if err := client.Run(); err != nil {
+		log.Fatalf("error running run phase: %v", err)
+	}
+	if err := client.Collect(); err != nil {
+		log.Fatalf("error running collect phase: %v", err)
+	}
+	if err := client.Reset(); err != nil {
+		log.Fatalf("error running reset phase: %v", err)
+	}
 }
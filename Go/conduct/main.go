@@ -2,7 +2,7 @@ package main
 
 import (
 	"conduct/lib"
-	"fmt"
+	"conduct/lib/log"
 	"os"
 
 	"github.com/go-ini/ini"
@@ -10,30 +10,42 @@ import (
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: conduct <config_file>")
-		os.Exit(1)
+		log.Fatalf("usage: conduct <config_file>")
 	}
 
 	configFile := os.Args[1]
 	cfg, err := ini.Load(configFile)
 	if err != nil {
-		fmt.Printf("Fail to read file: %v\n", err)
-		os.Exit(1)
+		log.Fatalf("fail to read file: %v", err)
 	}
 
 	// Example of reading values from the config file
 	resultHost := cfg.Section("default").Key("result_host").String()
 	resultPort := cfg.Section("default").Key("result_port").String()
+	downloadHost := cfg.Section("default").Key("download_host").String()
+	downloadPort := cfg.Section("default").Key("download_port").String()
+	players := cfg.Section("default").Key("players").Strings(",")
 
 	client := &lib.Client{
 		StartupPhase: *lib.NewPhase(resultHost, resultPort),
 		RunPhase:     *lib.NewPhase(resultHost, resultPort),
 		CollectPhase: *lib.NewPhase(resultHost, resultPort),
 		ResetPhase:   *lib.NewPhase(resultHost, resultPort),
+		DownloadHost: downloadHost,
+		DownloadPort: downloadPort,
+		Players:      players,
 	}
 
-	client.Startup()
-	client.Run()
-	client.Collect()
-	client.Reset()
+	if err := client.Startup(); err != nil {
+		log.Fatalf("error running startup phase: %v", err)
+	}
+	if err := client.Run(); err != nil {
+		log.Fatalf("error running run phase: %v", err)
+	}
+	if err := client.Collect(); err != nil {
+		log.Fatalf("error running collect phase: %v", err)
+	}
+	if err := client.Reset(); err != nil {
+		log.Fatalf("error running reset phase: %v", err)
+	}
 }
@@ -0,0 +1,118 @@
+package lib
+
+import (
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// BlockKey identifies a single downloaded block by which Phase it belongs
+// to and the byte offset within that Phase's encoded form.
+type BlockKey struct {
+	PhaseID string
+	Offset  int64
+}
+
+// BlockCache is an LRU cache of Phase blocks keyed by (phase, offset), so
+// a Player rerunning the same Phase (e.g. StartupPhase after a Reset)
+// does not have to refetch blocks it already downloaded. PerFileBytes
+// and TotalBytes cap how much of a single phase, and how much overall,
+// the cache is allowed to hold; either may be left at 0 to disable that
+// particular cap.
+type BlockCache struct {
+	cache        *lru.Cache[BlockKey, []byte]
+	perFileBytes int
+	totalBytes   int
+	usedByPhase  map[string]int
+	used         int
+}
+
+// NewBlockCache creates a BlockCache holding at most maxBlocks entries,
+// additionally bounded by perFileBytes and totalBytes.
+func NewBlockCache(perFileBytes, totalBytes, maxBlocks int) (*BlockCache, error) {
+	bc := &BlockCache{
+		perFileBytes: perFileBytes,
+		totalBytes:   totalBytes,
+		usedByPhase:  make(map[string]int),
+	}
+
+	cache, err := lru.NewWithEvict[BlockKey, []byte](maxBlocks, bc.onEvict)
+	if err != nil {
+		return nil, fmt.Errorf("error creating block cache: %v", err)
+	}
+	bc.cache = cache
+	return bc, nil
+}
+
+func (bc *BlockCache) onEvict(key BlockKey, value []byte) {
+	bc.used -= len(value)
+	bc.usedByPhase[key.PhaseID] -= len(value)
+}
+
+// Get returns the cached block for (phaseID, offset), if present.
+func (bc *BlockCache) Get(phaseID string, offset int64) ([]byte, bool) {
+	return bc.cache.Get(BlockKey{PhaseID: phaseID, Offset: offset})
+}
+
+// Put caches a block, evicting the oldest entries first if it would push
+// the owning phase or the cache as a whole over its configured budget.
+// Re-Putting a key already in the cache only charges the size delta
+// against the budget, since lru.Cache.Add silently overwrites the value
+// in place without going through onEvict.
+func (bc *BlockCache) Put(phaseID string, offset int64, block []byte) {
+	if bc.perFileBytes > 0 && len(block) > bc.perFileBytes {
+		return
+	}
+	if bc.totalBytes > 0 && len(block) > bc.totalBytes {
+		return
+	}
+
+	key := BlockKey{PhaseID: phaseID, Offset: offset}
+	oldSize := 0
+	if old, ok := bc.cache.Peek(key); ok {
+		oldSize = len(old)
+	}
+	delta := len(block) - oldSize
+
+	for bc.perFileBytes > 0 && bc.usedByPhase[phaseID]+delta > bc.perFileBytes {
+		if !bc.evictOldestInPhase(phaseID, key) {
+			break
+		}
+	}
+	for bc.totalBytes > 0 && bc.used+delta > bc.totalBytes {
+		if !bc.removeOldestExcept(key) {
+			break
+		}
+	}
+
+	bc.cache.Add(key, block)
+	bc.used += delta
+	bc.usedByPhase[phaseID] += delta
+}
+
+// evictOldestInPhase removes the least-recently-used block belonging to
+// phaseID, without disturbing other phases' entries, so enforcing one
+// phase's perFileBytes budget never evicts a different phase's blocks.
+// skip is excluded so a re-Put of an existing key can't evict itself
+// mid-update. It reports whether it found an entry to remove.
+func (bc *BlockCache) evictOldestInPhase(phaseID string, skip BlockKey) bool {
+	for _, key := range bc.cache.Keys() {
+		if key.PhaseID == phaseID && key != skip {
+			bc.cache.Remove(key)
+			return true
+		}
+	}
+	return false
+}
+
+// removeOldestExcept removes the cache's globally oldest entry unless
+// it is skip, so a re-Put of an existing key can't evict itself
+// mid-update. It reports whether it found an entry to remove.
+func (bc *BlockCache) removeOldestExcept(skip BlockKey) bool {
+	key, _, ok := bc.cache.GetOldest()
+	if !ok || key == skip {
+		return false
+	}
+	bc.cache.Remove(key)
+	return true
+}
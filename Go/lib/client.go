@@ -1,58 +1,47 @@
 package lib
 
-import (
-	"encoding/binary"
-	"net"
-)
-
 type Client struct {
 	StartupPhase Phase
 	RunPhase     Phase
 	CollectPhase Phase
 	ResetPhase   Phase
-}
 
-func (c *Client) Download(phase Phase) {
-	// Implement the download logic
+	// DownloadHost and DownloadPort are where Download listens for
+	// Players to pull a Phase from (see Player.Download, which dials
+	// this address and drives the request/response loop).
+	DownloadHost string
+	DownloadPort string
+
+	// Players lists the Players expected to pull the next Phase.
+	// Download blocks until that many have connected, then until all of
+	// them have finished downloading or one of them fails.
+	Players []string
+
+	// OnDataRequest, if set, answers the byte ranges a Player asks for
+	// out of some external source instead of the Phase's own encoded
+	// bytes. Every Player drives the same request/response protocol
+	// (see Player.Download), so leaving this unset just means Download
+	// answers requests directly out of the data it already encoded. See
+	// DataRequestCallback.
+	OnDataRequest DataRequestCallback
 }
 
-func (c *Client) Startup() {
+func (c *Client) Startup() error {
 	// Push the startup phase to the player
-	c.Download(c.StartupPhase)
+	return c.Download(c.StartupPhase)
 }
 
-func (c *Client) Run() {
+func (c *Client) Run() error {
 	// Push the run phase to the player
-	c.Download(c.RunPhase)
+	return c.Download(c.RunPhase)
 }
 
-func (c *Client) Collect() {
+func (c *Client) Collect() error {
 	// Push the collection phase to the player
-	c.Download(c.CollectPhase)
+	return c.Download(c.CollectPhase)
 }
 
-func (c *Client) Reset() {
+func (c *Client) Reset() error {
 	// Push the reset phase to the player
-	c.Download(c.ResetPhase)
-}
-
-func (c *Client) LenRecv(conn net.Conn) ([]byte, error) {
-	// Get the length of the message we're about to receive
-	buf := make([]byte, 4)
-	retbuf := []byte{}
-
-	_, err := conn.Read(buf)
-	if err != nil {
-		return nil, err
-	}
-
-	length := binary.BigEndian.Uint32(buf)
-
-	retbuf = make([]byte, length)
-	_, err = conn.Read(retbuf)
-	if err != nil {
-		return nil, err
-	}
-
-	return retbuf, nil
+	return c.Download(c.ResetPhase)
 }
@@ -0,0 +1,190 @@
+package lib
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"ChubbyGo/BaseServer"
+	"ChubbyGo/Connect"
+
+	"conduct/lib/log"
+)
+
+// DefaultCoordinatorPollInterval is how often the Coordinator re-checks
+// the set of locked player entries under a Phase's liveness directory
+// when no explicit interval is configured.
+const DefaultCoordinatorPollInterval = 2 * time.Second
+
+// Coordinator tracks an "ensure N players" target per Phase and logs
+// whenever the live count moves below or back up to that target. It
+// does not block or trigger dispatch itself: Client.Download does not
+// consult it. Each
+// Player creates a file under <root>/<phase>/<id> on startup and holds a
+// ReadLock on it as a liveness signal (see RegisterPlayer); if the
+// Player's session dies, Chubby releases that lock for us. The
+// ChubbyGo client this package talks to (see its other call sites in
+// conduct.go and play/player.go) only confirms Open/Create/Acquire/
+// Release/CheckToken — there is no directory-listing or watch primitive
+// to discover Players that never called RegisterPlayer, so the
+// Coordinator tracks the set of registered IDs itself and polls just
+// those entries' locks, acting only on a transition in the live subset.
+type Coordinator struct {
+	client *Connect.ClientConfigs
+	root   string
+
+	pollInterval time.Duration
+	targets      map[string]int
+
+	mu          sync.Mutex
+	registered  map[string]map[string]struct{} // phase -> set of registered player IDs
+	lastPlayers map[string]map[string]struct{}
+}
+
+// NewCoordinator builds a Coordinator rooted at root (e.g.
+// "/ls/ChubbyCell_Conductor/players"), talking to Chubby through client.
+func NewCoordinator(client *Connect.ClientConfigs, root string) *Coordinator {
+	return &Coordinator{
+		client:       client,
+		root:         root,
+		pollInterval: DefaultCoordinatorPollInterval,
+		targets:      make(map[string]int),
+		registered:   make(map[string]map[string]struct{}),
+		lastPlayers:  make(map[string]map[string]struct{}),
+	}
+}
+
+// EnsurePlayers sets the number of live Players phase must have before
+// it is considered ready to dispatch.
+func (co *Coordinator) EnsurePlayers(phase string, n int) {
+	co.targets[phase] = n
+}
+
+// RegisterPlayer has a Player create its liveness file under
+// <root>/<phase>/<id> and hold a ReadLock on it. The returned fd and
+// token should be kept by the caller and released on clean shutdown;
+// Release/Reset wiring for that happens in the Player itself. The file
+// is a BaseServer.PermanentFile, the only file type this package's
+// ChubbyGo call sites confirm exists; a Player that crashes without
+// releasing leaves the file behind, but reconcile still tells it apart
+// from a live Player by whether its ReadLock is still held, since
+// Chubby releases a session's locks when the session dies regardless of
+// the file's permanence.
+func (co *Coordinator) RegisterPlayer(phase, id string) (BaseServer.Fd, uint64, error) {
+	dir := fmt.Sprintf("%s/%s", co.root, phase)
+	ok, dirFd := co.client.Open(dir)
+	if !ok {
+		return BaseServer.Fd{}, 0, fmt.Errorf("error opening phase directory %s", dir)
+	}
+
+	ok, playerFd := co.client.Create(dirFd, BaseServer.PermanentFile, id)
+	if !ok {
+		return BaseServer.Fd{}, 0, fmt.Errorf("error creating player file %s/%s", dir, id)
+	}
+
+	ok, token := co.client.Acquire(playerFd, BaseServer.ReadLock, 0)
+	if !ok {
+		return BaseServer.Fd{}, 0, fmt.Errorf("error acquiring liveness lock for %s/%s", dir, id)
+	}
+	log.Debugf(log.FacetLock, "registered player %s/%s, token=%d", dir, id, token)
+
+	co.mu.Lock()
+	if co.registered[phase] == nil {
+		co.registered[phase] = make(map[string]struct{})
+	}
+	co.registered[phase][id] = struct{}{}
+	co.mu.Unlock()
+
+	return playerFd, token, nil
+}
+
+// Run polls every phase registered via EnsurePlayers until stop is
+// closed, and reconciles the live count against each phase's target.
+// Errors talking to Chubby are pushed to the returned channel rather
+// than aborting the loop; the channel holds one unread error, and
+// further errors that arrive while it is full are logged instead.
+func (co *Coordinator) Run(stop chan struct{}) <-chan error {
+	errs := make(chan error, 1)
+
+	go func() {
+		ticker := time.NewTicker(co.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				for phase, target := range co.targets {
+					co.reconcile(phase, target, errs)
+				}
+			}
+		}
+	}()
+
+	return errs
+}
+
+func (co *Coordinator) reconcile(phase string, target int, errs chan<- error) {
+	dir := fmt.Sprintf("%s/%s", co.root, phase)
+
+	co.mu.Lock()
+	ids := make([]string, 0, len(co.registered[phase]))
+	for id := range co.registered[phase] {
+		ids = append(ids, id)
+	}
+	co.mu.Unlock()
+
+	live := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		ok, childFd := co.client.Open(fmt.Sprintf("%s/%s", dir, id))
+		if !ok {
+			reportErr(errs, fmt.Errorf("error opening player file %s/%s", dir, id))
+			continue
+		}
+		// A held ReadLock makes a conflicting WriteLock attempt fail
+		// immediately (the kind of lock contention conduct/conduct.go
+		// exercises against start.sh); that is how we tell a live Player from a
+		// stale entry left behind by one that crashed without ever
+		// releasing its lock. When the probe succeeds the entry is
+		// stale, and we must release the probe's own WriteLock right
+		// away or it sits there and makes every later probe against
+		// this entry fail too, permanently misreporting it as live.
+		if ok, token := co.client.Acquire(childFd, BaseServer.WriteLock, 0); !ok {
+			live[id] = struct{}{}
+		} else {
+			co.client.Release(childFd, token)
+		}
+	}
+
+	if !playersChanged(co.lastPlayers[phase], live) {
+		return
+	}
+	co.lastPlayers[phase] = live
+
+	if len(live) < target {
+		log.Infof("phase %s: waiting for players, have %d want %d", phase, len(live), target)
+		return
+	}
+	log.Infof("phase %s: %d players ready (want %d)", phase, len(live), target)
+}
+
+func playersChanged(prev, live map[string]struct{}) bool {
+	if len(prev) != len(live) {
+		return true
+	}
+	for name := range live {
+		if _, ok := prev[name]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func reportErr(errs chan<- error, err error) {
+	select {
+	case errs <- err:
+	default:
+		log.Errorf("%v", err)
+	}
+}
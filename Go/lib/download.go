@@ -0,0 +1,149 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"net"
+
+	"conduct/lib/wire"
+)
+
+// DefaultDownloadBlockSize is the block size a Player requests a Phase
+// in when Player.BlockSize is unset.
+const DefaultDownloadBlockSize = 1 << 20 // 1 MiB
+
+// DataRequestCallback answers a Player's request for a byte range of a
+// Phase's encoded form, so a Client can serve those ranges from a source
+// of its own. Player.Download requests every block it does not already
+// have cached as soon as it connects; blocks are not fetched when a Step
+// first references them.
+type DataRequestCallback func(offset, length int64) ([]byte, error)
+
+// EncodePhase gob-encodes a Phase so it can be streamed to Players. Any
+// concrete Step implementations must be registered with gob.Register
+// before a Phase containing them is encoded.
+func EncodePhase(phase Phase) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(phase); err != nil {
+		return nil, fmt.Errorf("error encoding phase: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodePhase is the inverse of EncodePhase.
+func DecodePhase(data []byte) (Phase, error) {
+	var phase Phase
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&phase); err != nil {
+		return Phase{}, fmt.Errorf("error decoding phase: %v", err)
+	}
+	return phase, nil
+}
+
+// Download listens on c.DownloadHost:c.DownloadPort and serves phase to
+// every Player that connects there, until len(c.Players) Players have
+// each pulled it. Every Player drives the transfer as a request/response
+// loop (see Player.Download), asking only for the (offset, length)
+// ranges it doesn't already have cached, so Download never pushes a
+// block the Player didn't ask for. If OnDataRequest is set, those
+// requests are answered lazily out of some external source; otherwise
+// they're answered directly out of the Phase's own encoded bytes.
+func (c *Client) Download(phase Phase) error {
+	data, err := EncodePhase(phase)
+	if err != nil {
+		return err
+	}
+	if len(c.Players) == 0 {
+		return nil
+	}
+
+	onRequest := c.OnDataRequest
+	if onRequest == nil {
+		onRequest = defaultDataRequest(data)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(c.DownloadHost, c.DownloadPort))
+	if err != nil {
+		return fmt.Errorf("error starting download server: %v", err)
+	}
+	defer listener.Close()
+
+	results := make(chan error, len(c.Players))
+	for range c.Players {
+		conn, err := listener.Accept()
+		if err != nil {
+			return fmt.Errorf("error accepting player connection: %v", err)
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			results <- servePlayer(conn, int64(len(data)), onRequest)
+		}(conn)
+	}
+
+	for range c.Players {
+		if err := <-results; err != nil {
+			return fmt.Errorf("error serving player download: %v", err)
+		}
+	}
+	return nil
+}
+
+// defaultDataRequest answers a data request directly out of the
+// already-encoded Phase, for Clients that haven't wired up a custom
+// DataRequestCallback.
+func defaultDataRequest(data []byte) DataRequestCallback {
+	return func(offset, length int64) ([]byte, error) {
+		end := offset + length
+		if offset < 0 || length < 0 || end > int64(len(data)) {
+			return nil, fmt.Errorf("data request out of range: offset=%d length=%d size=%d", offset, length, len(data))
+		}
+		return data[offset:end], nil
+	}
+}
+
+// servePlayer handshakes over an already-accepted connection from a
+// Player, tells it how large the Phase is, and then answers its data
+// requests until it disconnects.
+func servePlayer(conn net.Conn, size int64, onRequest DataRequestCallback) error {
+	if _, err := wire.Handshake(conn); err != nil {
+		return fmt.Errorf("error negotiating wire version: %v", err)
+	}
+
+	if err := wire.WriteFrame(conn, wire.MsgPhasePush, int64ToBytes(size)); err != nil {
+		return err
+	}
+
+	return serveDataRequests(conn, onRequest)
+}
+
+// serveDataRequests waits for the Player to ask for specific byte ranges
+// and answers each one via onRequest until the Player closes the
+// connection, which it does once it has everything it needs.
+func serveDataRequests(conn net.Conn, onRequest DataRequestCallback) error {
+	for {
+		msgType, req, err := wire.ReadFrame(conn)
+		if err != nil {
+			return nil
+		}
+		if msgType != wire.MsgDataRequest || len(req) != 16 {
+			return fmt.Errorf("malformed data request: type=%d length=%d", msgType, len(req))
+		}
+		offset := int64(binary.BigEndian.Uint64(req[0:8]))
+		length := int64(binary.BigEndian.Uint64(req[8:16]))
+
+		block, err := onRequest(offset, length)
+		if err != nil {
+			return fmt.Errorf("error serving data request at offset %d: %v", offset, err)
+		}
+		if err := wire.WriteFrame(conn, wire.MsgDataBlock, block); err != nil {
+			return err
+		}
+	}
+}
+
+func int64ToBytes(v int64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(v))
+	return buf
+}
@@ -0,0 +1,98 @@
+// Package log is a small leveled logger with syncthing-style trace
+// facets: set CONDUCT_TRACE to a comma-separated list of facet names
+// (or "all") to turn on Debug output for just the subsystem being
+// diagnosed, e.g. CONDUCT_TRACE=net,lock.
+package log
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level orders the severities a message can be logged at.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	case LevelFatal:
+		return "FATAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Facet names recognized by CONDUCT_TRACE.
+const (
+	FacetNet   = "net"
+	FacetPhase = "phase"
+	FacetLock  = "lock"
+	FacetRPC   = "rpc"
+	FacetCache = "cache"
+)
+
+var (
+	mu     sync.Mutex
+	facets = parseFacets(os.Getenv("CONDUCT_TRACE"))
+)
+
+func parseFacets(v string) map[string]bool {
+	m := make(map[string]bool)
+	for _, f := range strings.Split(v, ",") {
+		f = strings.TrimSpace(f)
+		if f != "" {
+			m[f] = true
+		}
+	}
+	return m
+}
+
+// TraceEnabled reports whether CONDUCT_TRACE turned on debug output for
+// facet, either by name or via "all".
+func TraceEnabled(facet string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return facets["all"] || facets[facet]
+}
+
+// Debugf logs at Debug level, but only if facet is enabled via
+// CONDUCT_TRACE.
+func Debugf(facet, format string, args ...interface{}) {
+	if !TraceEnabled(facet) {
+		return
+	}
+	output(LevelDebug, format, args...)
+}
+
+func Infof(format string, args ...interface{})  { output(LevelInfo, format, args...) }
+func Warnf(format string, args ...interface{})  { output(LevelWarn, format, args...) }
+func Errorf(format string, args ...interface{}) { output(LevelError, format, args...) }
+
+// Fatalf logs at Fatal level and then exits the process, mirroring the
+// os.Exit(1) that callers previously did by hand after printing.
+func Fatalf(format string, args ...interface{}) {
+	output(LevelFatal, format, args...)
+	os.Exit(1)
+}
+
+func output(level Level, format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "%s [%s] %s\n", time.Now().Format(time.RFC3339), level, fmt.Sprintf(format, args...))
+}
@@ -1,12 +1,16 @@
 package lib
 
 import (
+	"context"
 	"fmt"
-	"net"
+	"time"
+
+	"conduct/lib/log"
+	"conduct/lib/rpc"
 )
 
 type Step interface {
-	Run() RetVal
+	Run(ctx context.Context) RetVal
 }
 
 type RetVal struct {
@@ -14,15 +18,15 @@ type RetVal struct {
 	Message string
 }
 
-func (r RetVal) Send(conn net.Conn) {
-	fmt.Fprintf(conn, "%d: %s\n", r.Code, r.Message)
-}
-
 const (
 	RETVAL_DONE = iota
+	// RETVAL_ABORTED marks a Step or Phase that did not finish because
+	// shutdown was requested, distinguishing it from a clean completion.
+	RETVAL_ABORTED
 )
 
 type Phase struct {
+	ID         string
 	ResultHost string
 	ResultPort string
 	Steps      []Step
@@ -46,31 +50,52 @@ func (p *Phase) Append(step Step) {
 	p.Steps = append(p.Steps, step)
 }
 
-func (p *Phase) Run() {
-	// Execute all the steps
-	for _, step := range p.Steps {
-		ret := step.Run()
+// Run executes all the steps, logging a timing/outcome event for each
+// one under the "phase" trace facet. If ctx is cancelled before a step
+// starts, Run stops dispatching further steps and records a
+// RETVAL_ABORTED result in its place so the collector can tell clean
+// completion from termination.
+func (p *Phase) Run(ctx context.Context) {
+	for i, step := range p.Steps {
+		select {
+		case <-ctx.Done():
+			log.Infof("phase %s: aborting before step %d: %v", p.ID, i, ctx.Err())
+			p.Results = append(p.Results, RetVal{Code: RETVAL_ABORTED, Message: fmt.Sprintf("aborted before step %d: %v", i, ctx.Err())})
+			return
+		default:
+		}
+
+		start := time.Now()
+		ret := step.Run(ctx)
+		elapsed := time.Since(start)
+
 		p.Results = append(p.Results, ret)
+		log.Debugf(log.FacetPhase, "phase %s: step %d finished in %s, code=%d message=%q", p.ID, i, elapsed, ret.Code, ret.Message)
 	}
 }
 
-func (p *Phase) ReturnResults() {
-	// Return the results of the steps
-	for _, result := range p.Results {
-		conn, err := net.Dial("tcp", net.JoinHostPort(p.ResultHost, p.ResultPort))
-		if err != nil {
-			fmt.Println("Error connecting:", err)
-			continue
+// ReturnResults reports every Step result to the collector over a
+// single persistent JSON-RPC connection, then marks the Phase complete.
+func (p *Phase) ReturnResults() error {
+	client := rpc.NewClient(p.ResultHost, p.ResultPort)
+	defer client.Close()
+
+	for i, result := range p.Results {
+		req := rpc.StepResult{
+			PhaseID:   p.ID,
+			StepIndex: i,
+			Code:      result.Code,
+			Message:   result.Message,
+		}
+		var ack rpc.Ack
+		if err := client.Call("Collector.ReportStep", &req, &ack); err != nil {
+			return fmt.Errorf("error reporting step %d: %v", i, err)
 		}
-		result.Send(conn)
-		conn.Close()
 	}
-	conn, err := net.Dial("tcp", net.JoinHostPort(p.ResultHost, p.ResultPort))
-	if err != nil {
-		fmt.Println("Error connecting:", err)
-		return
+
+	var ack rpc.Ack
+	if err := client.Call("Collector.PhaseComplete", &p.ID, &ack); err != nil {
+		return fmt.Errorf("error reporting phase complete: %v", err)
 	}
-	ret := RetVal{Code: RETVAL_DONE, Message: "phases complete"}
-	ret.Send(conn)
-	conn.Close()
+	return nil
 }
@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+	"time"
+)
+
+// DefaultReconnectBackoff is the initial delay between reconnect
+// attempts; it doubles on each consecutive failure up to
+// DefaultMaxReconnectBackoff.
+const (
+	DefaultReconnectBackoff    = 100 * time.Millisecond
+	DefaultMaxReconnectBackoff = 5 * time.Second
+)
+
+// Client is a persistent JSON-RPC client to the collector. It
+// reconnects with exponential backoff if the underlying connection is
+// lost, so a whole Phase's worth of results share one TCP connection
+// instead of opening a new one per result.
+type Client struct {
+	addr string
+
+	mu      sync.Mutex
+	rpc     *rpc.Client
+	backoff time.Duration
+}
+
+// NewClient returns a Client that lazily dials host:port on its first
+// Call.
+func NewClient(host, port string) *Client {
+	return &Client{addr: net.JoinHostPort(host, port)}
+}
+
+// Call invokes serviceMethod on the collector, reconnecting once and
+// retrying if the persistent connection had gone away.
+func (c *Client) Call(serviceMethod string, args, reply interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rpc == nil {
+		if err := c.connectLocked(); err != nil {
+			return err
+		}
+	}
+
+	err := c.rpc.Call(serviceMethod, args, reply)
+	if isConnLost(err) {
+		c.rpc = nil
+		if err := c.connectLocked(); err != nil {
+			return err
+		}
+		err = c.rpc.Call(serviceMethod, args, reply)
+	}
+	return err
+}
+
+func (c *Client) connectLocked() error {
+	conn, err := net.Dial("tcp", c.addr)
+	if err != nil {
+		c.sleepBackoffLocked()
+		return fmt.Errorf("error connecting to collector %s: %v", c.addr, err)
+	}
+	c.rpc = jsonrpc.NewClient(conn)
+	c.backoff = 0
+	return nil
+}
+
+func (c *Client) sleepBackoffLocked() {
+	if c.backoff == 0 {
+		c.backoff = DefaultReconnectBackoff
+	}
+	time.Sleep(c.backoff)
+	c.backoff *= 2
+	if c.backoff > DefaultMaxReconnectBackoff {
+		c.backoff = DefaultMaxReconnectBackoff
+	}
+}
+
+// Close releases the underlying connection, if any.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rpc == nil {
+		return nil
+	}
+	err := c.rpc.Close()
+	c.rpc = nil
+	return err
+}
+
+func isConnLost(err error) bool {
+	return errors.Is(err, rpc.ErrShutdown) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe) || errors.Is(err, net.ErrClosed)
+}
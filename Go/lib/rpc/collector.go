@@ -0,0 +1,85 @@
+// Package rpc is a JSON-RPC collector service, replacing the
+// ad-hoc gob/text framings Phase.ReturnResults and Player.sendResult
+// used to speak to the collector over a fresh TCP connection per
+// result. Methods follow net/rpc's convention (exported, two
+// arguments, returns error) so Collector can be served over
+// net/rpc/jsonrpc, which speaks JSON-RPC 1.0.
+package rpc
+
+import "sync"
+
+// PlayerInfo identifies a Player registering with the collector.
+type PlayerInfo struct {
+	ID   string
+	Host string
+	Port string
+}
+
+// StepResult reports one Step's outcome within a Phase.
+type StepResult struct {
+	PhaseID   string
+	StepIndex int
+	Code      int
+	Message   string
+}
+
+// Ack is the empty reply every Collector method returns on success;
+// failures are surfaced through the RPC call's own error instead.
+type Ack struct{}
+
+// Collector is the JSON-RPC service exposed by the result
+// collector.
+type Collector struct {
+	mu      sync.Mutex
+	results map[string][]StepResult
+	done    map[string]bool
+	players map[string]PlayerInfo
+}
+
+// NewCollector returns an empty Collector ready to be served.
+func NewCollector() *Collector {
+	return &Collector{
+		results: make(map[string][]StepResult),
+		done:    make(map[string]bool),
+		players: make(map[string]PlayerInfo),
+	}
+}
+
+// ReportStep records a single Step's result for its Phase.
+func (c *Collector) ReportStep(result *StepResult, reply *Ack) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[result.PhaseID] = append(c.results[result.PhaseID], *result)
+	return nil
+}
+
+// PhaseComplete marks a Phase as having reported all of its results.
+func (c *Collector) PhaseComplete(phaseID *string, reply *Ack) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.done[*phaseID] = true
+	return nil
+}
+
+// RegisterPlayer records a Player that has announced itself to the
+// collector.
+func (c *Collector) RegisterPlayer(info *PlayerInfo, reply *Ack) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.players[info.ID] = *info
+	return nil
+}
+
+// Results returns the Step results reported so far for phaseID.
+func (c *Collector) Results(phaseID string) []StepResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]StepResult(nil), c.results[phaseID]...)
+}
+
+// Done reports whether PhaseComplete has been called for phaseID.
+func (c *Collector) Done(phaseID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done[phaseID]
+}
@@ -0,0 +1,52 @@
+package rpc
+
+import (
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+)
+
+// Server listens for JSON-RPC connections and serves a Collector on
+// each one, so a single connection from a Phase or Player can carry
+// every result it reports instead of dialing fresh per result.
+type Server struct {
+	listener net.Listener
+}
+
+// NewServer registers collector and starts listening on host:port.
+func NewServer(host, port string, collector *Collector) (*Server, error) {
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.Register(collector); err != nil {
+		return nil, fmt.Errorf("error registering collector: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, fmt.Errorf("error listening: %v", err)
+	}
+
+	s := &Server{listener: listener}
+	go s.serve(rpcServer)
+	return s, nil
+}
+
+func (s *Server) serve(rpcServer *rpc.Server) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go rpcServer.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// Addr returns the address the Server is listening on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close stops accepting new connections.
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
@@ -0,0 +1,89 @@
+package lib
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"conduct/lib/log"
+)
+
+// DefaultGraceTimeout bounds how long Shutdown waits for in-flight work
+// to finish once a shutdown signal arrives before it gives up on it.
+const DefaultGraceTimeout = 30 * time.Second
+
+// Shutdown coordinates graceful termination under an orchestrator
+// (k8s, systemd, …): it installs handlers for SIGINT/SIGTERM/SIGHUP,
+// cancels a context.Context when one arrives, and gives in-flight work
+// GraceTimeout to wrap up before Wait gives up on it.
+type Shutdown struct {
+	GraceTimeout time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	sigCh  chan os.Signal
+
+	once sync.Once
+}
+
+// NewShutdown installs signal handlers and returns a Shutdown ready to
+// be waited on via Context/Wait.
+func NewShutdown() *Shutdown {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Shutdown{
+		GraceTimeout: DefaultGraceTimeout,
+		ctx:          ctx,
+		cancel:       cancel,
+		sigCh:        make(chan os.Signal, 1),
+	}
+
+	signal.Notify(s.sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	go s.watch()
+	return s
+}
+
+func (s *Shutdown) watch() {
+	sig, ok := <-s.sigCh
+	if !ok {
+		return
+	}
+	log.Infof("received %s, shutting down (grace period %s)", sig, s.GraceTimeout)
+	s.cancel()
+}
+
+// Context is cancelled as soon as a shutdown signal arrives.
+func (s *Shutdown) Context() context.Context {
+	return s.ctx
+}
+
+// Wait blocks until done is closed (work finished on its own) or a
+// shutdown signal arrives. In the latter case it blocks further, up to
+// GraceTimeout, giving the in-flight work a chance to finish before
+// Wait gives up on it and returns anyway.
+func (s *Shutdown) Wait(done <-chan struct{}) {
+	select {
+	case <-done:
+		return
+	case <-s.ctx.Done():
+	}
+
+	select {
+	case <-done:
+	case <-time.After(s.GraceTimeout):
+		log.Warnf("grace period elapsed with work still in flight")
+	}
+}
+
+// Stop removes the installed signal handlers and cancels Context, for
+// use when the caller is shutting down on its own rather than via a
+// signal.
+func (s *Shutdown) Stop() {
+	s.once.Do(func() {
+		signal.Stop(s.sigCh)
+		close(s.sigCh)
+		s.cancel()
+	})
+}
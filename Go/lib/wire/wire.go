@@ -0,0 +1,111 @@
+// Package wire is conductor's versioned framing: 4-byte magic, 1-byte
+// version, 1-byte message type, 4-byte BE payload length, then payload.
+// It replaces the download path's previous length-prefix-only framing,
+// which (like Client.LenRecv before it) read with a single conn.Read
+// instead of io.ReadFull and so could silently truncate on a short TCP
+// read.
+//
+// MsgStepResult, MsgPhaseDone, MsgPlayerRegister and MsgAbort are part
+// of that protocol vocabulary but, in this tree, are not currently sent:
+// step results, phase completion, player registration and aborts (see
+// RETVAL_ABORTED) travel over the typed JSON-RPC collector service in
+// lib/rpc instead, which gives that fixed-shape traffic request/response
+// semantics and reconnect/backoff that a raw frame would have to
+// reinvent. Only the Download path, which moves arbitrary-length byte
+// ranges rather than a fixed RPC shape, actually speaks this framing.
+// The four reserved types stay defined so the wire protocol itself
+// still matches its original spec and a future non-RPC consumer of this
+// package has them to build on.
+package wire
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Magic identifies a conductor frame.
+var Magic = [4]byte{'C', 'N', 'D', 'R'}
+
+// Version is the highest protocol version this build speaks.
+const Version byte = 1
+
+// Message types carried in a frame.
+const (
+	MsgPhasePush = iota + 1
+	MsgStepResult
+	MsgPhaseDone
+	MsgPlayerRegister
+	MsgAbort
+	MsgDataRequest
+	MsgDataBlock
+)
+
+const headerLen = 4 + 1 + 1 + 4 // magic + version + type + length
+
+// WriteFrame writes a single frame for msgType carrying payload.
+func WriteFrame(w io.Writer, msgType byte, payload []byte) error {
+	header := make([]byte, headerLen)
+	copy(header[0:4], Magic[:])
+	header[4] = Version
+	header[5] = msgType
+	binary.BigEndian.PutUint32(header[6:10], uint32(len(payload)))
+
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("error writing frame header: %v", err)
+	}
+	if _, err := w.Write(payload); err != nil {
+		return fmt.Errorf("error writing frame payload: %v", err)
+	}
+	return nil
+}
+
+// ReadFrame reads a single frame written by WriteFrame. It uses
+// io.ReadFull throughout, so a short TCP read can't truncate the
+// header or the payload out from under it.
+func ReadFrame(r io.Reader) (msgType byte, payload []byte, err error) {
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, fmt.Errorf("error reading frame header: %v", err)
+	}
+
+	var magic [4]byte
+	copy(magic[:], header[0:4])
+	if magic != Magic {
+		return 0, nil, fmt.Errorf("bad frame magic %x", header[0:4])
+	}
+	if version := header[4]; version > Version {
+		return 0, nil, fmt.Errorf("unsupported wire version %d, this build speaks up to %d", version, Version)
+	}
+
+	msgType = header[5]
+	length := binary.BigEndian.Uint32(header[6:10])
+
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, fmt.Errorf("error reading frame payload: %v", err)
+	}
+	return msgType, payload, nil
+}
+
+// Handshake exchanges each side's highest supported version and
+// returns the lower of the two. WriteFrame does not take the negotiated
+// version: it always stamps frames with this build's Version, and
+// ReadFrame rejects any frame whose version is higher than its own, so
+// a peer built with a higher Version cannot be read by this build.
+func Handshake(rw io.ReadWriter) (byte, error) {
+	if _, err := rw.Write([]byte{Version}); err != nil {
+		return 0, fmt.Errorf("error sending handshake: %v", err)
+	}
+
+	peer := make([]byte, 1)
+	if _, err := io.ReadFull(rw, peer); err != nil {
+		return 0, fmt.Errorf("error reading handshake: %v", err)
+	}
+
+	negotiated := Version
+	if peer[0] < negotiated {
+		negotiated = peer[0]
+	}
+	return negotiated, nil
+}
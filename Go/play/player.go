@@ -6,53 +6,203 @@ import (
 	"ChubbyGo/Connect"
 	"os"
 
-	"bytes"
-	"encoding/gob"
+	"conduct/lib"
+	"conduct/lib/log"
+	"conduct/lib/rpc"
+	"conduct/lib/wire"
+	"context"
+	"encoding/binary"
 	"fmt"
-	"log"
 	"net"
 )
 
+// DefaultPerFileCacheBytes and DefaultTotalCacheBytes bound how much of a
+// downloaded Phase, and how much across all Phases, a Player keeps
+// cached between runs.
+const (
+	DefaultPerFileCacheBytes = 64 << 20  // 64 MiB
+	DefaultTotalCacheBytes   = 256 << 20 // 256 MiB
+	DefaultMaxCachedBlocks   = 1024
+)
+
 type Player struct {
 	Host string
 	Port string
+
+	BlockSize         int
+	PerFileCacheBytes int
+	TotalCacheBytes   int
+
+	cache      *lib.BlockCache
+	resultConn *rpc.Client
+
+	coordClient   *Connect.ClientConfigs
+	livenessFd    BaseServer.Fd
+	livenessToken uint64
+	registered    bool
 }
 
 func NewPlayer(host, port string) *Player {
 	return &Player{
-		Host: host,
-		Port: port,
+		Host:              host,
+		Port:              port,
+		PerFileCacheBytes: DefaultPerFileCacheBytes,
+		TotalCacheBytes:   DefaultTotalCacheBytes,
 	}
 }
 
-func (p *Player) sendResult(result map[string]string) error {
-	conn, err := net.Dial("tcp", net.JoinHostPort(p.Host, p.Port))
+// Register has the Player create its liveness file through coord and
+// remembers the resulting lock so Shutdown can release it later.
+func (p *Player) Register(coord *lib.Coordinator, client *Connect.ClientConfigs, phase, id string) error {
+	fd, token, err := coord.RegisterPlayer(phase, id)
 	if err != nil {
-		return fmt.Errorf("error connecting: %v", err)
+		return err
 	}
-	defer conn.Close()
+	p.coordClient = client
+	p.livenessFd = fd
+	p.livenessToken = token
+	p.registered = true
+	return nil
+}
+
+// Shutdown releases the Player's liveness lock, if it holds one. Call
+// it once Run has returned (cleanly or aborted) so an exiting Player
+// doesn't leave its lock held for the Coordinator to wait out a session
+// timeout on.
+func (p *Player) Shutdown() {
+	if !p.registered {
+		return
+	}
+	if ok := p.coordClient.Release(p.livenessFd, p.livenessToken); ok {
+		log.Debugf(log.FacetLock, "released liveness lock")
+	} else {
+		log.Warnf("error releasing liveness lock")
+	}
+}
 
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-	err = enc.Encode(result)
+// resultClient lazily creates the Player's persistent connection to the
+// result collector so a whole Phase's worth of results share one
+// connection instead of dialing fresh per result.
+func (p *Player) resultClient() *rpc.Client {
+	if p.resultConn == nil {
+		p.resultConn = rpc.NewClient(p.Host, p.Port)
+	}
+	return p.resultConn
+}
+
+// blockCache lazily creates the Player's LRU block cache.
+func (p *Player) blockCache() (*lib.BlockCache, error) {
+	if p.cache == nil {
+		cache, err := lib.NewBlockCache(p.PerFileCacheBytes, p.TotalCacheBytes, DefaultMaxCachedBlocks)
+		if err != nil {
+			return nil, err
+		}
+		p.cache = cache
+	}
+	return p.cache, nil
+}
+
+// Download fetches phaseID from a Client's download server at
+// host:port, requesting only the blocks that aren't already in the
+// Player's cache (e.g. because this same Phase was already run once).
+func (p *Player) Download(phaseID, host, port string) ([]byte, error) {
+	cache, err := p.blockCache()
 	if err != nil {
-		return fmt.Errorf("error encoding result: %v", err)
+		return nil, err
 	}
 
-	_, err = conn.Write(buf.Bytes())
+	conn, err := net.Dial("tcp", net.JoinHostPort(host, port))
 	if err != nil {
-		return fmt.Errorf("error sending result: %v", err)
+		return nil, fmt.Errorf("error connecting: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := wire.Handshake(conn); err != nil {
+		return nil, fmt.Errorf("error negotiating wire version: %v", err)
+	}
+
+	msgType, totalBuf, err := wire.ReadFrame(conn)
+	if err != nil {
+		return nil, fmt.Errorf("error receiving phase size: %v", err)
+	}
+	if msgType != wire.MsgPhasePush || len(totalBuf) != 8 {
+		return nil, fmt.Errorf("malformed phase push: type=%d length=%d", msgType, len(totalBuf))
+	}
+	total := int64(binary.BigEndian.Uint64(totalBuf))
+
+	blockSize := p.BlockSize
+	if blockSize <= 0 {
+		blockSize = lib.DefaultDownloadBlockSize
 	}
 
+	data := make([]byte, total)
+	for offset := int64(0); offset < total; offset += int64(blockSize) {
+		length := int64(blockSize)
+		if offset+length > total {
+			length = total - offset
+		}
+
+		if block, ok := cache.Get(phaseID, offset); ok {
+			copy(data[offset:offset+length], block)
+			continue
+		}
+
+		req := make([]byte, 16)
+		binary.BigEndian.PutUint64(req[0:8], uint64(offset))
+		binary.BigEndian.PutUint64(req[8:16], uint64(length))
+		if err := wire.WriteFrame(conn, wire.MsgDataRequest, req); err != nil {
+			return nil, fmt.Errorf("error requesting block at offset %d: %v", offset, err)
+		}
+
+		msgType, block, err := wire.ReadFrame(conn)
+		if err != nil {
+			return nil, fmt.Errorf("error receiving block at offset %d: %v", offset, err)
+		}
+		if msgType != wire.MsgDataBlock {
+			return nil, fmt.Errorf("unexpected message type %d for block at offset %d", msgType, offset)
+		}
+		cache.Put(phaseID, offset, block)
+		copy(data[offset:offset+length], block)
+	}
+
+	return data, nil
+}
+
+// sendResult is a thin wrapper around the Player's persistent JSON-RPC
+// connection to the collector.
+func (p *Player) sendResult(phaseID string, stepIndex, code int, message string) error {
+	req := rpc.StepResult{
+		PhaseID:   phaseID,
+		StepIndex: stepIndex,
+		Code:      code,
+		Message:   message,
+	}
+	var ack rpc.Ack
+	if err := p.resultClient().Call("Collector.ReportStep", &req, &ack); err != nil {
+		return fmt.Errorf("error sending result: %v", err)
+	}
 	return nil
 }
 
-func (p *Player) Run() {
-	// Implement the logic to run the player
-	result := map[string]string{"status": "success", "message": "Player ran successfully"}
-	err := p.sendResult(result)
+// Run executes the Player's work, honoring ctx so an in-flight Run can
+// be interrupted by a shutdown signal. Each result is reported to the
+// collector as soon as it's produced (see sendResult), so there is no
+// separate buffer to flush on the way out; an aborted Run still reports
+// a RETVAL_ABORTED result so the collector can tell it apart from a
+// clean completion.
+func (p *Player) Run(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		if err := p.sendResult("startup", 0, lib.RETVAL_ABORTED, fmt.Sprintf("aborted: %v", ctx.Err())); err != nil {
+			log.Errorf("error sending result: %v", err)
+		}
+		return
+	default:
+	}
+
+	err := p.sendResult("startup", 0, lib.RETVAL_DONE, "Player ran successfully")
 	if err != nil {
-		fmt.Printf("Error sending result: %v\n", err)
+		log.Errorf("error sending result: %v", err)
 	}
 }
 
@@ -60,35 +210,59 @@ func main() {
 	clientConfigs := Connect.CreateClient()
 	err := clientConfigs.StartClient()
 	if err != nil {
-		log.Println(err.Error())
+		log.Errorf("%v", err)
 	}
 	clientConfigs.SetUniqueFlake(uint64(os.Getpid())) // Use a random number for multiple tests
 
+	shutdown := lib.NewShutdown()
+	defer shutdown.Stop()
+
+	coord := lib.NewCoordinator(clientConfigs, "/ls/ChubbyCell_Conductor/players")
+	playerID := fmt.Sprintf("%d", os.Getpid())
+	player := NewPlayer("localhost", "8080")
+	if err := player.Register(coord, clientConfigs, "startup", playerID); err != nil {
+		log.Errorf("error registering with coordinator: %v", err)
+	}
+	defer player.Shutdown()
+
+	startupData, err := player.Download("startup", "localhost", "9090")
+	if err != nil {
+		log.Errorf("error downloading startup phase: %v", err)
+	} else if startupPhase, err := lib.DecodePhase(startupData); err != nil {
+		log.Errorf("error decoding startup phase: %v", err)
+	} else {
+		log.Infof("downloaded startup phase with %d steps", len(startupPhase.Steps))
+	}
+
 	filename := "/ls/ChubbyCell_Conductor/test.sh"
 	// Open a directory and get a handle
 	ok, fileFd := clientConfigs.Open(filename)
 	if ok {
-		fmt.Printf("Get fd success, instanceSeq is %d\n", fileFd.InstanceSeq)
+		log.Debugf(log.FacetLock, "get fd success, instanceSeq is %d", fileFd.InstanceSeq)
 	} else {
-		fmt.Printf("Error!\n")
+		log.Warnf("error opening %s", filename)
 	}
 
 	// Lock the newly created file
 	ok, token := clientConfigs.Acquire(fileFd, BaseServer.ReadLock, 0)
 	if ok {
-		fmt.Printf("Acquire (%s) success, Token is %d\n", filename, token)
+		log.Debugf(log.FacetLock, "acquire (%s) success, token is %d", filename, token)
 	} else {
-		fmt.Printf("Acquire Error!\n")
+		log.Warnf("acquire error on %s", filename)
 	}
 
 	// Delete the file with the token you locked yourself
 	ok = clientConfigs.Release(fileFd, token)
 	if ok {
-		fmt.Printf("release (%s) success.\n", filename)
+		log.Debugf(log.FacetLock, "release (%s) success", filename)
 	} else {
-		fmt.Printf("Release Error!\n")
+		log.Warnf("release error on %s", filename)
 	}
 
-	player := NewPlayer("localhost", "8080")
-	player.Run()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		player.Run(shutdown.Context())
+	}()
+	shutdown.Wait(done)
 }